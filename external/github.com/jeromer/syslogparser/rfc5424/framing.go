@@ -0,0 +1,58 @@
+package rfc5424
+
+import (
+	"bufio"
+	"errors"
+)
+
+var ErrFrameTooLarge = errors.New("Octet-counted frame exceeds buffer")
+
+// ScanOctetCounting is a bufio.SplitFunc implementing the octet-counting framing
+// method of RFC6587 section 3.4.1: each message is prefixed by its length in
+// bytes as ASCII digits, followed by a single space, e.g. "52 <34>1 ...".
+func ScanOctetCounting(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	sp := -1
+	for i, b := range data {
+		if b == ' ' {
+			sp = i
+			break
+		}
+		if b < '0' || b > '9' {
+			return 0, nil, errors.New("Octet-counting frame: MSG-LEN is not numeric")
+		}
+		if i > 9 {
+			return 0, nil, ErrFrameTooLarge
+		}
+	}
+	if sp == -1 {
+		if atEOF {
+			return 0, nil, errors.New("Octet-counting frame: missing MSG-LEN separator")
+		}
+		return 0, nil, nil // need more data
+	}
+
+	msgLen := 0
+	for _, b := range data[:sp] {
+		msgLen = msgLen*10 + int(b-'0')
+	}
+
+	frameEnd := sp + 1 + msgLen
+	if len(data) < frameEnd {
+		if atEOF {
+			return 0, nil, errors.New("Octet-counting frame: truncated message")
+		}
+		return 0, nil, nil // need more data
+	}
+
+	return frameEnd, data[sp+1 : frameEnd], nil
+}
+
+// ScanNonTransparent is a bufio.SplitFunc implementing the non-transparent-framing
+// method of RFC6587 section 3.4.2, where each syslog message is terminated by '\n'.
+func ScanNonTransparent(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return bufio.ScanLines(data, atEOF)
+}
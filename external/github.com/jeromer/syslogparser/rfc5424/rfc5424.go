@@ -0,0 +1,392 @@
+package rfc5424
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/jeromer/syslogparser"
+)
+
+const (
+	// NILVALUE is the RFC5424 placeholder for an absent field (section 6.2.3).
+	NILVALUE = "-"
+
+	maxHostnameLen = 255
+	maxAppNameLen  = 48
+	maxProcIDLen   = 128
+	maxMsgIDLen    = 32
+)
+
+var (
+	// utf8BOM marks MSG as being UTF-8 encoded, per RFC5424 section 6.4.
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+	ErrVersionUnknownFormat  = errors.New("Version format unknown")
+	ErrInvalidStructuredData = errors.New("Invalid structured data")
+	errExpectedSpace         = errors.New("Expected space separator")
+	errFieldInvalid          = errors.New("Invalid header field")
+)
+
+type Parser struct {
+	buff     []byte
+	cursor   int
+	l        int
+	priority syslogparser.Priority
+	version  int
+	header   header
+	sd       map[string]map[string]string
+	message  string
+	location *time.Location
+	hostname string
+}
+
+type ParseResult struct {
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	Version        int
+	Message        string
+	StructuredData map[string]map[string]string
+	Priority       int
+	Facility       int
+	Severity       int
+}
+
+type header struct {
+	timestamp time.Time
+	hostname  string
+	appName   string
+	procID    string
+	msgID     string
+}
+
+func NewParser(buff []byte) *Parser {
+	return &Parser{
+		buff:     buff,
+		cursor:   0,
+		l:        len(buff),
+		location: time.UTC,
+	}
+}
+
+func (p *Parser) Location(location *time.Location) {
+	p.location = location
+}
+
+func (p *Parser) Hostname(hostname string) {
+	p.hostname = hostname
+}
+
+func (p *Parser) Parse() error {
+	pri, err := p.parsePriority()
+	if err != nil {
+		return err
+	}
+
+	version, err := p.parseVersion()
+	if err != nil {
+		return err
+	}
+
+	if err := p.expectSpace(); err != nil {
+		return err
+	}
+
+	hdr, err := p.parseHeader()
+	if err != nil {
+		return err
+	}
+
+	if err := p.expectSpace(); err != nil {
+		return err
+	}
+
+	sd, err := p.parseStructuredData()
+	if err != nil {
+		return err
+	}
+
+	msg, err := p.parseMessage()
+	if err != syslogparser.ErrEOL {
+		return err
+	}
+
+	p.priority = pri
+	p.version = version
+	p.header = hdr
+	p.sd = sd
+	p.message = msg
+
+	return nil
+}
+
+func (p *Parser) Dump() syslogparser.LogParts {
+	return syslogparser.LogParts{
+		"timestamp":       p.header.timestamp,
+		"hostname":        p.header.hostname,
+		"app_name":        p.header.appName,
+		"proc_id":         p.header.procID,
+		"msg_id":          p.header.msgID,
+		"version":         p.version,
+		"structured_data": p.sd,
+		"content":         p.message,
+		"priority":        p.priority.P,
+		"facility":        p.priority.F.Value,
+		"severity":        p.priority.S.Value,
+	}
+}
+
+func (p *Parser) DumpParseResult() ParseResult {
+	return ParseResult{
+		Timestamp:      p.header.timestamp,
+		Hostname:       p.header.hostname,
+		AppName:        p.header.appName,
+		ProcID:         p.header.procID,
+		MsgID:          p.header.msgID,
+		Version:        p.version,
+		Message:        p.message,
+		StructuredData: p.sd,
+		Priority:       p.priority.P,
+		Facility:       p.priority.F.Value,
+		Severity:       p.priority.S.Value,
+	}
+}
+
+func (p *Parser) parsePriority() (syslogparser.Priority, error) {
+	return syslogparser.ParsePriority(p.buff, &p.cursor, p.l)
+}
+
+// https://tools.ietf.org/html/rfc5424#section-6.2.2 : only "1" is defined today.
+func (p *Parser) parseVersion() (int, error) {
+	if p.cursor >= p.l || p.buff[p.cursor] < '0' || p.buff[p.cursor] > '9' {
+		return 0, ErrVersionUnknownFormat
+	}
+
+	start := p.cursor
+	for p.cursor < p.l && p.buff[p.cursor] >= '0' && p.buff[p.cursor] <= '9' {
+		p.cursor++
+	}
+
+	version := 0
+	for _, b := range p.buff[start:p.cursor] {
+		version = version*10 + int(b-'0')
+	}
+
+	return version, nil
+}
+
+func (p *Parser) expectSpace() error {
+	if p.cursor >= p.l {
+		return syslogparser.ErrEOL
+	}
+	if p.buff[p.cursor] != ' ' {
+		return errExpectedSpace
+	}
+	p.cursor++
+	return nil
+}
+
+func (p *Parser) parseHeader() (header, error) {
+	hdr := header{}
+
+	ts, err := p.parseTimestamp()
+	if err != nil {
+		return hdr, err
+	}
+	hdr.timestamp = ts
+	if err := p.expectSpace(); err != nil {
+		return hdr, err
+	}
+
+	hostname, err := p.parseBoundedField(maxHostnameLen)
+	if err != nil {
+		return hdr, syslogparser.ErrInvalidHostname
+	}
+	if p.hostname != "" {
+		hostname = p.hostname
+	}
+	hdr.hostname = hostname
+	if err := p.expectSpace(); err != nil {
+		return hdr, err
+	}
+
+	appName, err := p.parseBoundedField(maxAppNameLen)
+	if err != nil {
+		return hdr, syslogparser.ErrInvalidAppName
+	}
+	hdr.appName = appName
+	if err := p.expectSpace(); err != nil {
+		return hdr, err
+	}
+
+	procID, err := p.parseBoundedField(maxProcIDLen)
+	if err != nil {
+		return hdr, syslogparser.ErrInvalidProcId
+	}
+	hdr.procID = procID
+	if err := p.expectSpace(); err != nil {
+		return hdr, err
+	}
+
+	msgID, err := p.parseBoundedField(maxMsgIDLen)
+	if err != nil {
+		return hdr, syslogparser.ErrInvalidMsgId
+	}
+	hdr.msgID = msgID
+
+	return hdr, nil
+}
+
+// https://tools.ietf.org/html/rfc5424#section-6.3 : full ISO 8601 date-time,
+// with optional fractional seconds, or NILVALUE.
+func (p *Parser) parseTimestamp() (time.Time, error) {
+	if p.peekNilvalue() {
+		p.cursor += len(NILVALUE)
+		return time.Time{}, nil
+	}
+
+	start := p.cursor
+	for p.cursor < p.l && p.buff[p.cursor] != ' ' {
+		p.cursor++
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, string(p.buff[start:p.cursor]))
+	if err != nil {
+		return ts, syslogparser.ErrTimestampUnknownFormat
+	}
+
+	return ts.In(p.location), nil
+}
+
+// parseBoundedField reads a NILVALUE or a run of non-space bytes up to maxLen long,
+// used for HOSTNAME/APP-NAME/PROCID/MSGID (RFC5424 section 6).
+func (p *Parser) parseBoundedField(maxLen int) (string, error) {
+	if p.peekNilvalue() {
+		p.cursor += len(NILVALUE)
+		return "", nil
+	}
+
+	start := p.cursor
+	for p.cursor < p.l && p.buff[p.cursor] != ' ' {
+		p.cursor++
+	}
+
+	field := p.buff[start:p.cursor]
+	if len(field) == 0 || len(field) > maxLen {
+		return "", errFieldInvalid
+	}
+
+	return string(field), nil
+}
+
+func (p *Parser) peekNilvalue() bool {
+	return p.cursor < p.l && p.buff[p.cursor] == '-' &&
+		(p.cursor+1 == p.l || p.buff[p.cursor+1] == ' ')
+}
+
+// https://tools.ietf.org/html/rfc5424#section-6.3 : NILVALUE or one or more
+// "[SD-ID key=\"value\" ...]" elements, with \, " and ] escaped by \.
+func (p *Parser) parseStructuredData() (map[string]map[string]string, error) {
+	if p.peekNilvalue() {
+		p.cursor += len(NILVALUE)
+		return nil, nil
+	}
+
+	sd := make(map[string]map[string]string)
+
+	for p.cursor < p.l && p.buff[p.cursor] == '[' {
+		p.cursor++
+
+		idStart := p.cursor
+		for p.cursor < p.l && p.buff[p.cursor] != ' ' && p.buff[p.cursor] != ']' {
+			p.cursor++
+		}
+		if p.cursor >= p.l {
+			return nil, ErrInvalidStructuredData
+		}
+		id := string(p.buff[idStart:p.cursor])
+
+		params := make(map[string]string)
+		for p.cursor < p.l && p.buff[p.cursor] == ' ' {
+			p.cursor++ // SP
+
+			nameStart := p.cursor
+			for p.cursor < p.l && p.buff[p.cursor] != '=' {
+				p.cursor++
+			}
+			if p.cursor >= p.l {
+				return nil, ErrInvalidStructuredData
+			}
+			name := string(p.buff[nameStart:p.cursor])
+			p.cursor++ // '='
+
+			value, err := p.parseSDValue()
+			if err != nil {
+				return nil, err
+			}
+			params[name] = value
+		}
+
+		if p.cursor >= p.l || p.buff[p.cursor] != ']' {
+			return nil, ErrInvalidStructuredData
+		}
+		p.cursor++ // ']'
+
+		sd[id] = params
+	}
+
+	return sd, nil
+}
+
+// parseSDValue reads a double-quoted PARAM-VALUE, unescaping \\, \" and \].
+func (p *Parser) parseSDValue() (string, error) {
+	if p.cursor >= p.l || p.buff[p.cursor] != '"' {
+		return "", ErrInvalidStructuredData
+	}
+	p.cursor++ // opening '"'
+
+	var value []byte
+	for p.cursor < p.l {
+		b := p.buff[p.cursor]
+		if b == '\\' && p.cursor+1 < p.l {
+			next := p.buff[p.cursor+1]
+			if next == '\\' || next == '"' || next == ']' {
+				value = append(value, next)
+				p.cursor += 2
+				continue
+			}
+		}
+		if b == '"' {
+			p.cursor++ // closing '"'
+			return string(value), nil
+		}
+		value = append(value, b)
+		p.cursor++
+	}
+
+	return "", ErrInvalidStructuredData
+}
+
+// https://tools.ietf.org/html/rfc5424#section-6.4 : MSG is optionally preceded by
+// a UTF-8 BOM, which marks its encoding but is not part of the content.
+func (p *Parser) parseMessage() (string, error) {
+	if p.cursor < p.l && p.buff[p.cursor] == ' ' {
+		p.cursor++
+	}
+
+	if bytes.HasPrefix(p.buff[p.cursor:p.l], utf8BOM) {
+		p.cursor += len(utf8BOM)
+	}
+
+	if p.cursor > p.l {
+		return "", syslogparser.ErrEOL
+	}
+
+	content := p.buff[p.cursor:p.l]
+	p.cursor += len(content)
+
+	return string(content), syslogparser.ErrEOL
+}
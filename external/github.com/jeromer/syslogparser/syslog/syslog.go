@@ -0,0 +1,39 @@
+// Package syslog picks the right RFC3164 or RFC5424 parser for a raw syslog
+// message, so callers that receive messages from mixed-version sources don't
+// have to special-case the format themselves.
+package syslog
+
+import (
+	"bytes"
+
+	"github.com/jeromer/syslogparser"
+	"github.com/jeromer/syslogparser/rfc3164"
+	"github.com/jeromer/syslogparser/rfc5424"
+)
+
+// Parse detects whether buff is an RFC5424 or RFC3164 syslog message, parses
+// it with the matching parser and returns the generic syslogparser.Parser so
+// callers can use Dump() without caring which format was detected.
+func Parse(buff []byte) (syslogparser.Parser, error) {
+	var p syslogparser.Parser
+	if IsRFC5424(buff) {
+		p = rfc5424.NewParser(buff)
+	} else {
+		p = rfc3164.NewParser(buff)
+	}
+
+	if err := p.Parse(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// IsRFC5424 peeks past the "<PRI>" prefix for the "1 " VERSION SP sequence that
+// every RFC5424 message carries and no RFC3164 message does.
+func IsRFC5424(buff []byte) bool {
+	end := bytes.IndexByte(buff, '>')
+	if end < 0 || end+2 >= len(buff) {
+		return false
+	}
+	return buff[end+1] == '1' && buff[end+2] == ' '
+}
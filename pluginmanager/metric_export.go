@@ -14,6 +14,7 @@
 package pluginmanager
 
 import (
+	"math"
 	goruntimemetrics "runtime/metrics"
 	"strconv"
 	"strings"
@@ -84,43 +85,156 @@ func GetGoPluginMetrics() []map[string]string {
 	return metrics
 }
 
+// key is the metric key in runtime/metrics, value is the agent's metric key.
+// For KindFloat64Histogram metrics, the agent key is used as a prefix and
+// expanded into "<prefix>_count", "_sum", "_p50", "_p90" and "_p99".
+//
+// Units are derived from the agent key suffix so new entries can be added
+// here without touching the read loop below:
+//   - "_mb" -> value is in bytes, convert to megabytes
+//   - "_ms" -> value is in seconds, convert to milliseconds
+//   - anything else is emitted as-is
+var agentStatMetricNames = map[string]string{
+	// mem. All memory mapped by the Go runtime into the current process as read-write. Note that this does not include memory mapped by code called via cgo or via the syscall package. Sum of all metrics in /memory/classes.
+	"/memory/classes/total:bytes": helper.MetricAgentMemoryGo,
+	// go routines cnt. Count of live goroutines.
+	"/sched/goroutines:goroutines": helper.MetricAgentGoRoutinesTotal,
+	// heap memory currently in use by application objects.
+	"/memory/classes/heap/objects:bytes": "agent_go_heap_inuse_mb",
+	// count of objects, live or unswept, tracked by the garbage collector.
+	"/gc/heap/objects:objects": "agent_go_heap_objects",
+	// memory currently reserved for goroutine stacks.
+	"/memory/classes/heap/stacks:bytes": "agent_go_stack_inuse_mb",
+	// count of completed GC cycles since the program started.
+	"/gc/cycles/total:gc-cycles": "agent_go_gc_cycles_total",
+	// cumulative CPU time spent performing GC since the program started.
+	"/cpu/classes/gc/total:cpu-seconds": "agent_go_gc_cpu_seconds_total",
+	// distribution of the time goroutines have spent in the scheduler in a runnable state before actually running.
+	"/sched/latencies:seconds": "agent_go_sched_latency_ms",
+	// cumulative approximate time goroutines have spent blocked on a mutex or runtime-internal lock.
+	"/sync/mutex/wait/total:seconds": "agent_go_mutex_wait_ms",
+}
+
 // go 进程级指标，由C++部分注册
 func GetAgentStat() []map[string]string {
 	metrics := []map[string]string{}
 	metric := map[string]string{}
-	// key is the metric key in runtime/metrics, value is agent's metric key
-	metricNames := map[string]string{
-		// mem. All memory mapped by the Go runtime into the current process as read-write. Note that this does not include memory mapped by code called via cgo or via the syscall package. Sum of all metrics in /memory/classes.
-		"/memory/classes/total:bytes": helper.MetricAgentMemoryGo,
-		// go routines cnt. Count of live goroutines.
-		"/sched/goroutines:goroutines": helper.MetricAgentGoRoutinesTotal,
-	}
 
 	// metrics to read from runtime/metrics
-	samples := make([]goruntimemetrics.Sample, 0)
-	for name := range metricNames {
+	samples := make([]goruntimemetrics.Sample, 0, len(agentStatMetricNames))
+	for name := range agentStatMetricNames {
 		samples = append(samples, goruntimemetrics.Sample{Name: name})
 	}
 	goruntimemetrics.Read(samples)
 
 	// push results to recrods
 	for _, sample := range samples {
-		key := metricNames[sample.Name]
+		key := agentStatMetricNames[sample.Name]
 		value := sample.Value
-		valueStr := ""
 		switch value.Kind() {
 		case goruntimemetrics.KindUint64:
-			if strings.HasSuffix(key, "_mb") {
-				valueStr = strconv.FormatUint(value.Uint64()/1024/1024, 10)
-			} else {
-				valueStr = strconv.FormatUint(value.Uint64(), 10)
-			}
+			metric[key] = formatUintMetric(key, value.Uint64())
 		case goruntimemetrics.KindFloat64:
-			valueStr = strconv.FormatFloat(value.Float64(), 'g', -1, 64)
+			metric[key] = formatFloatMetric(key, value.Float64())
+		case goruntimemetrics.KindFloat64Histogram:
+			for suffix, v := range histogramStats(value.Float64Histogram()) {
+				if suffix == "_count" {
+					// _count is an event count, not a duration: never run it through key's _ms/_mb conversion.
+					metric[key+suffix] = strconv.FormatFloat(v, 'g', -1, 64)
+					continue
+				}
+				metric[key+suffix] = formatFloatMetric(key, v)
+			}
 		}
-		metric[key] = valueStr
 	}
 
 	metrics = append(metrics, metric)
 	return metrics
 }
+
+// formatUintMetric applies the unit conversion implied by key's suffix to an integer-valued sample.
+func formatUintMetric(key string, v uint64) string {
+	if strings.HasSuffix(key, "_mb") {
+		return strconv.FormatUint(v/1024/1024, 10)
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// formatFloatMetric applies the unit conversion implied by key's suffix to a float-valued sample.
+func formatFloatMetric(key string, v float64) string {
+	switch {
+	case strings.HasSuffix(key, "_mb"):
+		v /= 1024 * 1024
+	case strings.HasSuffix(key, "_ms"):
+		v *= 1000
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// histogramStats reduces a KindFloat64Histogram sample to the scalars agents can consume:
+// the sample count, an approximate sum (bucket midpoint * count) and the p50/p90/p99
+// computed by linearly interpolating within the bucket that contains each quantile.
+// The returned map is keyed by the metric-name suffix to append, e.g. "_count", "_p99".
+func histogramStats(h *goruntimemetrics.Float64Histogram) map[string]float64 {
+	var count uint64
+	var sum float64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		count += c
+		sum += histogramBucketMidpoint(h, i) * float64(c)
+	}
+
+	return map[string]float64{
+		"_count": float64(count),
+		"_sum":   sum,
+		"_p50":   histogramQuantile(h, count, 0.5),
+		"_p90":   histogramQuantile(h, count, 0.9),
+		"_p99":   histogramQuantile(h, count, 0.99),
+	}
+}
+
+// histogramBucketMidpoint returns the midpoint of bucket i, falling back to the finite
+// edge when the other edge is infinite (the first and last buckets of a Float64Histogram
+// are unbounded below/above respectively).
+func histogramBucketMidpoint(h *goruntimemetrics.Float64Histogram, i int) float64 {
+	lo, hi := h.Buckets[i], h.Buckets[i+1]
+	if math.IsInf(hi, 1) {
+		return lo
+	}
+	if math.IsInf(lo, -1) {
+		return hi
+	}
+	return (lo + hi) / 2
+}
+
+// histogramQuantile estimates the p-quantile (0 < p <= 1) of a Float64Histogram by
+// locating the bucket that crosses the target rank and interpolating linearly across it.
+func histogramQuantile(h *goruntimemetrics.Float64Histogram, count uint64, p float64) float64 {
+	if count == 0 {
+		return 0
+	}
+
+	target := p * float64(count)
+	var cumulative uint64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		prev := cumulative
+		cumulative += c
+		if float64(cumulative) >= target {
+			lo, hi := h.Buckets[i], h.Buckets[i+1]
+			if math.IsInf(hi, 1) {
+				return lo
+			}
+			if math.IsInf(lo, -1) {
+				return hi
+			}
+			frac := (target - float64(prev)) / float64(c)
+			return lo + frac*(hi-lo)
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
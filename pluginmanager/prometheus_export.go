@@ -0,0 +1,190 @@
+// Copyright 2024 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package pluginmanager
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	PrometheusFormatText        = "text"
+	PrometheusFormatOpenMetrics = "openmetrics"
+)
+
+// metricSample is a single exposition-ready metric: its name, the labels carried by
+// the "label.*" keys of its source record and the value carried by its "value.*" key.
+type metricSample struct {
+	name   string
+	labels map[string]string
+	value  string
+}
+
+// WritePrometheusExposition renders every Go-side plugin metric (GetGoDirectMetrics and
+// GetGoCppProvidedMetrics) as a Prometheus text or OpenMetrics exposition document, so
+// it can be scraped directly instead of being routed through the C++ bridge.
+func WritePrometheusExposition(w io.Writer, format string) error {
+	grouped, names := groupMetricSamples(collectMetricSamples())
+
+	for _, name := range names {
+		metricType := prometheusMetricType(name)
+		// OpenMetrics 1.0 requires HELP/TYPE to name the metric family without the
+		// "_total" suffix that the counter's samples themselves still carry.
+		familyName := name
+		if format == PrometheusFormatOpenMetrics && metricType == "counter" {
+			familyName = strings.TrimSuffix(name, "_total")
+		}
+
+		if _, err := fmt.Fprintf(w, "# HELP %s %s exported by iLogtail.\n", familyName, familyName); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", familyName, metricType); err != nil {
+			return err
+		}
+		for _, sample := range grouped[name] {
+			if err := writePrometheusSample(w, sample); err != nil {
+				return err
+			}
+		}
+	}
+
+	if format == PrometheusFormatOpenMetrics {
+		if _, err := fmt.Fprint(w, "# EOF\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewPrometheusHandler returns an http.Handler that serves the current Go-side plugin
+// metrics in the given exposition format wherever it is mounted.
+func NewPrometheusHandler(format string) http.Handler {
+	contentType := prometheusContentType(format)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if err := WritePrometheusExposition(w, format); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func prometheusContentType(format string) string {
+	if format == PrometheusFormatOpenMetrics {
+		return "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	}
+	return "text/plain; version=0.0.4; charset=utf-8"
+}
+
+// collectMetricSamples walks GetGoDirectMetrics (records keyed by "label.*"/"value.*",
+// see its doc comment) and GetGoCppProvidedMetrics (records keyed directly by metric
+// name, see GetAgentStat) and flattens both into a single sample list.
+func collectMetricSamples() []metricSample {
+	records := append(GetGoDirectMetrics(), GetGoCppProvidedMetrics()...)
+	samples := make([]metricSample, 0, len(records))
+
+	for _, record := range records {
+		labels := make(map[string]string)
+		for key, value := range record {
+			if strings.HasPrefix(key, "label.") {
+				labels[strings.TrimPrefix(key, "label.")] = value
+			}
+		}
+
+		for key, value := range record {
+			if strings.HasPrefix(key, "label.") {
+				continue
+			}
+			name := strings.TrimPrefix(key, "value.")
+			samples = append(samples, metricSample{name: name, labels: labels, value: value})
+		}
+	}
+
+	return samples
+}
+
+// groupMetricSamples buckets samples by metric name and returns the names in sorted
+// order so metric families are emitted in a stable order. Samples within a name keep
+// the order in which they were collected, which is not sorted.
+func groupMetricSamples(samples []metricSample) (map[string][]metricSample, []string) {
+	grouped := make(map[string][]metricSample)
+	names := make([]string, 0, len(samples))
+
+	for _, sample := range samples {
+		if _, ok := grouped[sample.name]; !ok {
+			names = append(names, sample.name)
+		}
+		grouped[sample.name] = append(grouped[sample.name], sample)
+	}
+	sort.Strings(names)
+
+	return grouped, names
+}
+
+// prometheusMetricType infers a metric's TYPE from its name suffix: "_total" is a
+// monotonic counter, "_bytes"/"_mb" are point-in-time gauges, and anything else
+// defaults to a gauge too.
+func prometheusMetricType(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_total"):
+		return "counter"
+	case strings.HasSuffix(name, "_bytes"), strings.HasSuffix(name, "_mb"):
+		return "gauge"
+	default:
+		return "gauge"
+	}
+}
+
+func writePrometheusSample(w io.Writer, sample metricSample) error {
+	if len(sample.labels) == 0 {
+		_, err := fmt.Fprintf(w, "%s %s\n", sample.name, sample.value)
+		return err
+	}
+
+	keys := make([]string, 0, len(sample.labels))
+	for key := range sample.labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(sample.name)
+	b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(key)
+		b.WriteString(`="`)
+		b.WriteString(escapePrometheusLabelValue(sample.labels[key]))
+		b.WriteByte('"')
+	}
+	b.WriteString("} ")
+	b.WriteString(sample.value)
+	b.WriteByte('\n')
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapePrometheusLabelValue escapes a label value per the exposition format spec:
+// backslash, double-quote and newline must be backslash-escaped.
+func escapePrometheusLabelValue(value string) string {
+	return prometheusLabelEscaper.Replace(value)
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)